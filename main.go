@@ -8,6 +8,8 @@ import (
 	"time"
 	"fmt"
 
+	"github.com/segmentio/kafka-go"
+	"otel-go-app-example/messaging"
 	"otel-go-app-example/otelsetup"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"otel-go-app-example/utils"
@@ -34,6 +36,15 @@ func main() {
 	serviceName := os.Getenv("SERVICE_NAME")
 	log.Printf("Starting OpenTelemetry with service name: %s", serviceName)
 
+	// Initialize OpenTelemetry first: otelsql.Register/RegisterDBStatsMetrics
+	// below capture the current global tracer/meter provider at call time, so
+	// InitDB must run after the real providers are installed or the wrapped
+	// driver stays bound to the no-op providers forever.
+	shutdown, err := otelsetup.InitProvider()
+	if err != nil {
+		log.Fatalf("Failed to initialize OpenTelemetry: %v", err)
+	}
+
 	// Initialize the database connection
 	errMysql := utils.InitDB("kienlt", "123123") // this is just an example, please replace with your own database credentials xD
 	if errMysql != nil {
@@ -42,11 +53,31 @@ func main() {
 	}
 	defer utils.DB.Close() // Close the connection when done
 
-	// Initialize OpenTelemetry
-	shutdown, err := otelsetup.InitProvider()
-	if err != nil {
-		log.Fatalf("Failed to initialize OpenTelemetry: %v", err)
-	}
+	// Initialize Kafka producer and start a background consumer so a single
+	// trace can be followed from HTTP -> producer -> broker -> consumer.
+	kafkaBrokers := []string{"127.0.0.1:9092"}
+	const kafkaTopic = "demo-events"
+	utils.InitMessaging(kafkaBrokers, kafkaTopic)
+
+	consumer := messaging.NewConsumer(kafkaBrokers, kafkaTopic, "otel-go-app-sample")
+	consumerCtx, stopConsumer := context.WithCancel(context.Background())
+	defer stopConsumer()
+	go func() {
+		err := consumer.Run(consumerCtx, func(ctx context.Context, msg kafka.Message) {
+			log.Printf("received kafka message: key=%s value=%s", msg.Key, msg.Value)
+		})
+		if err != nil {
+			log.Printf("kafka consumer stopped: %v", err)
+		}
+	}()
+	defer consumer.Close()
+
+	// Record Go runtime metrics (goroutines, heap, GC pauses, CPU) on the
+	// same OTLP pipeline so latency spikes can be correlated with GC/scheduler
+	// pressure without standing up a separate Prometheus scrape endpoint.
+	runtimeCtx, stopRuntimeInstrumentation := context.WithCancel(context.Background())
+	defer stopRuntimeInstrumentation()
+	go otelsetup.StartRuntimeInstrumentation(runtimeCtx, 15*time.Second)
 
 	// Set up the Gorilla Mux router
 	router := utils.SetupRouter()
@@ -0,0 +1,155 @@
+// Package messaging provides Kafka producer/consumer helpers that propagate
+// the active OTel trace context through message headers, so a trace can span
+// HTTP -> producer -> broker -> consumer.
+package messaging
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("messaging")
+
+// headerCarrier adapts a []kafka.Header slice to propagation.TextMapCarrier
+// so the OTel propagator can inject/extract trace context into Kafka message
+// headers.
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+func (c headerCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c headerCarrier) Set(key, value string) {
+	for i, h := range *c.headers {
+		if h.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, len(*c.headers))
+	for i, h := range *c.headers {
+		keys[i] = h.Key
+	}
+	return keys
+}
+
+// Producer publishes messages to a Kafka topic with trace propagation.
+type Producer struct {
+	writer *kafka.Writer
+	topic  string
+}
+
+// NewProducer returns a Producer writing to topic on the given brokers.
+func NewProducer(brokers []string, topic string) *Producer {
+	return &Producer{
+		writer: &kafka.Writer{
+			Addr:     kafka.TCP(brokers...),
+			Topic:    topic,
+			Balancer: &kafka.LeastBytes{},
+		},
+		topic: topic,
+	}
+}
+
+// Publish starts a messaging.publish span, injects the active trace context
+// into the Kafka message headers, and writes the message to the topic.
+func (p *Producer) Publish(ctx context.Context, key, value []byte) error {
+	ctx, span := tracer.Start(ctx, "messaging.publish", trace.WithAttributes(
+		semconv.MessagingSystem("kafka"),
+		semconv.MessagingDestinationName(p.topic),
+	))
+	defer span.End()
+
+	msg := kafka.Message{Key: key, Value: value}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{headers: &msg.Headers})
+
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("error publishing kafka message: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("messaging.kafka.partition", msg.Partition))
+	return nil
+}
+
+// Close flushes and closes the underlying writer.
+func (p *Producer) Close() error {
+	return p.writer.Close()
+}
+
+// Consumer reads messages from a Kafka topic and resumes the trace context
+// carried in each message's headers.
+type Consumer struct {
+	reader *kafka.Reader
+	topic  string
+}
+
+// NewConsumer returns a Consumer reading topic on the given brokers as part
+// of groupID.
+func NewConsumer(brokers []string, topic, groupID string) *Consumer {
+	return &Consumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: brokers,
+			Topic:   topic,
+			GroupID: groupID,
+		}),
+		topic: topic,
+	}
+}
+
+// Handler processes a single consumed Kafka message with a traced context.
+type Handler func(ctx context.Context, msg kafka.Message)
+
+// Run reads messages until ctx is cancelled, extracting the producer's trace
+// context from each message's headers and starting a messaging.receive span
+// as its child (with a nested messaging.process span) before handing the
+// message (and traced context) to handle.
+func (c *Consumer) Run(ctx context.Context, handle Handler) error {
+	for {
+		msg, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("error reading kafka message: %w", err)
+		}
+
+		msgCtx := otel.GetTextMapPropagator().Extract(ctx, headerCarrier{headers: &msg.Headers})
+		msgCtx, span := tracer.Start(msgCtx, "messaging.receive", trace.WithAttributes(
+			semconv.MessagingSystem("kafka"),
+			semconv.MessagingDestinationName(c.topic),
+			attribute.Int("messaging.kafka.partition", msg.Partition),
+		))
+
+		func() {
+			defer span.End()
+			processCtx, processSpan := tracer.Start(msgCtx, "messaging.process")
+			defer processSpan.End()
+			handle(processCtx, msg)
+		}()
+	}
+}
+
+// Close stops the underlying reader.
+func (c *Consumer) Close() error {
+	return c.reader.Close()
+}
@@ -0,0 +1,430 @@
+package otelsetup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/apache/arrow/go/v14/arrow"
+	"github.com/apache/arrow/go/v14/arrow/array"
+	"github.com/apache/arrow/go/v14/arrow/ipc"
+	"github.com/apache/arrow/go/v14/arrow/memory"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// rawBytesCodec is a grpc.encoding.Codec that passes []byte payloads through
+// unmodified instead of treating them as proto.Message, which is what the
+// default registered "proto" codec requires. The Arrow IPC payload built by
+// encodeBatch is already a complete wire-format byte slice, so it must be
+// forced past the proto codec's type assertion with grpc.ForceCodec rather
+// than handed to SendMsg under the default codec.
+type rawBytesCodec struct{}
+
+func (rawBytesCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("otelsetup: rawBytesCodec: unsupported type %T", v)
+	}
+	return b, nil
+}
+
+func (rawBytesCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("otelsetup: rawBytesCodec: unsupported type %T", v)
+	}
+	*b = data
+	return nil
+}
+
+func (rawBytesCodec) Name() string { return "raw" }
+
+// arrowTracesMethod is the bidirectional-streaming RPC used by the OTel
+// Protocol with Apache Arrow (OTAP) extension, kept here as a raw method
+// descriptor since we don't vendor generated arrow collector stubs.
+const arrowTracesMethod = "/opentelemetry.proto.experimental.arrow.v1.ArrowTracesService/ArrowTraces"
+
+var arrowRecordSchema = arrow.NewSchema(
+	[]arrow.Field{
+		{Name: "batch_id", Type: arrow.PrimitiveTypes.Uint64},
+		{Name: "otlp_span_bytes", Type: arrow.BinaryTypes.Binary},
+	},
+	nil,
+)
+
+// newSpanExporterWithArrow wraps newSpanExporter, substituting an OTel-Arrow
+// streaming exporter when OTEL_EXPORTER_ARROW_ENABLED=true. It falls back to
+// the standard OTLP exporter returned by newSpanExporter whenever the arrow
+// exporter can't be constructed or the collector doesn't speak the arrow
+// service (Unimplemented).
+func newSpanExporterWithArrow(ctx context.Context) (sdktrace.SpanExporter, error) {
+	fallback, err := newSpanExporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, _ := strconv.ParseBool(os.Getenv("OTEL_EXPORTER_ARROW_ENABLED"))
+	if !enabled {
+		return fallback, nil
+	}
+
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "127.0.0.1:4317"
+	}
+
+	arrowExp, err := newArrowExporter(ctx, endpoint, fallback)
+	if err != nil {
+		// The collector might not support OTAP at all; keep the caller
+		// moving with plain OTLP rather than failing startup.
+		return fallback, nil
+	}
+	return arrowExp, nil
+}
+
+// arrowStream tracks one leg of the multiplexed Arrow stream along with how
+// many bytes it currently has in flight, so the exporter can pick the
+// least-loaded stream for the next batch ("best-of-N" prioritization).
+type arrowStream struct {
+	mu            sync.Mutex
+	clientStream  grpc.ClientStream
+	inFlightBytes int64
+	closed        bool
+}
+
+// arrowExporter streams spans to the collector as Arrow record batches over
+// N long-lived gRPC streams, falling back to standard OTLP export if the
+// collector answers Unimplemented or closes a stream with io.EOF. Other
+// stream failures (a failed send, or reconnects exhausted) are returned to
+// the caller as errors rather than falling back.
+type arrowExporter struct {
+	conn     *grpc.ClientConn
+	fallback sdktrace.SpanExporter
+	alloc    memory.Allocator
+
+	mu            sync.Mutex
+	streams       []*arrowStream
+	nextBatchID   uint64
+	batchSize     int
+	batchTimeout  time.Duration
+	usingFallback bool
+}
+
+func newArrowExporter(ctx context.Context, endpoint string, fallback sdktrace.SpanExporter) (*arrowExporter, error) {
+	numStreams := 4
+	if v := os.Getenv("OTEL_EXPORTER_ARROW_STREAMS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			numStreams = n
+		}
+	}
+	batchSize := 256
+	if v := os.Getenv("OTEL_EXPORTER_ARROW_BATCH_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			batchSize = n
+		}
+	}
+	batchTimeout := 200 * time.Millisecond
+	if v := os.Getenv("OTEL_EXPORTER_ARROW_BATCH_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			batchTimeout = d
+		}
+	}
+
+	conn, err := grpc.DialContext(ctx, endpoint,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock())
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial arrow collector endpoint: %w", err)
+	}
+
+	e := &arrowExporter{
+		conn:         conn,
+		fallback:     fallback,
+		alloc:        memory.NewGoAllocator(),
+		batchSize:    batchSize,
+		batchTimeout: batchTimeout,
+	}
+
+	for i := 0; i < numStreams; i++ {
+		s, err := e.openStream(ctx)
+		if err != nil {
+			if status.Code(err) == codes.Unimplemented {
+				return nil, err
+			}
+			// Best-effort: keep whatever streams did succeed; reconnect is
+			// attempted lazily on the next export if none are healthy.
+			continue
+		}
+		e.streams = append(e.streams, s)
+	}
+	if len(e.streams) == 0 {
+		return nil, errors.New("otelsetup: no arrow streams could be opened")
+	}
+
+	return e, nil
+}
+
+func (e *arrowExporter) openStream(ctx context.Context) (*arrowStream, error) {
+	cs, err := e.conn.NewStream(ctx, &grpc.StreamDesc{
+		StreamName:    "ArrowTraces",
+		ClientStreams: true,
+		ServerStreams: true,
+	}, arrowTracesMethod, grpc.ForceCodec(rawBytesCodec{}))
+	if err != nil {
+		return nil, err
+	}
+	s := &arrowStream{clientStream: cs}
+	// grpc-go only surfaces a stream's terminal error/status through
+	// RecvMsg, never SendMsg, so a dedicated reader goroutine is the only
+	// reliable way to notice the collector doesn't speak this service.
+	go e.drainStream(s)
+	return s, nil
+}
+
+// drainStream reads server responses off s until it errors, which is how a
+// collector that doesn't implement the Arrow service (or closes the stream
+// for any other reason) actually surfaces to a gRPC client. Unimplemented
+// (and a bare stream close reported as io.EOF, since collectors lacking this
+// service typically close rather than answering with a status) both flip the
+// exporter to standard OTLP for subsequent batches.
+func (e *arrowExporter) drainStream(s *arrowStream) {
+	for {
+		var msg []byte
+		err := s.clientStream.RecvMsg(&msg)
+		if err == nil {
+			continue
+		}
+
+		if status.Code(err) == codes.Unimplemented || errors.Is(err, io.EOF) {
+			e.mu.Lock()
+			e.usingFallback = true
+			e.mu.Unlock()
+		}
+
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		return
+	}
+}
+
+// leastLoadedStream returns the stream with the fewest in-flight bytes,
+// reconnecting lazily if every stream has been marked closed.
+func (e *arrowExporter) leastLoadedStream(ctx context.Context) (*arrowStream, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var best *arrowStream
+	bestLoad := int64(math.MaxInt64)
+	for _, s := range e.streams {
+		s.mu.Lock()
+		closed := s.closed
+		load := s.inFlightBytes
+		s.mu.Unlock()
+		if closed {
+			continue
+		}
+		if load < bestLoad {
+			best, bestLoad = s, load
+		}
+	}
+	if best != nil {
+		return best, nil
+	}
+
+	// All streams are down; reconnect with backoff before giving up.
+	var lastErr error
+	backoff := 100 * time.Millisecond
+	for attempt := 0; attempt < 3; attempt++ {
+		s, err := e.openStream(ctx)
+		if err == nil {
+			e.streams = append(e.streams, s)
+			return s, nil
+		}
+		lastErr = err
+		if status.Code(err) == codes.Unimplemented {
+			return nil, err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return nil, fmt.Errorf("otelsetup: all arrow streams unavailable: %w", lastErr)
+}
+
+// ExportSpans encodes spans into an Arrow record batch and sends it on the
+// least-loaded stream, falling back to standard OTLP export on Unimplemented.
+func (e *arrowExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	if e.usingFallback {
+		e.mu.Unlock()
+		return e.fallback.ExportSpans(ctx, spans)
+	}
+	e.mu.Unlock()
+
+	// Split further if the processor handed us more spans than one Arrow
+	// record batch should carry (the size half of the size/time trigger;
+	// the time half is already enforced upstream by the BatchSpanProcessor).
+	for start := 0; start < len(spans); start += e.batchSize {
+		end := start + e.batchSize
+		if end > len(spans) {
+			end = len(spans)
+		}
+		if err := e.exportChunk(ctx, spans[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *arrowExporter) exportChunk(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	ctx, cancel := context.WithTimeout(ctx, e.batchTimeout)
+	defer cancel()
+
+	payload, err := e.encodeBatch(spans)
+	if err != nil {
+		return fmt.Errorf("otelsetup: failed to encode arrow batch: %w", err)
+	}
+
+	stream, err := e.leastLoadedStream(ctx)
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			e.mu.Lock()
+			e.usingFallback = true
+			e.mu.Unlock()
+			return e.fallback.ExportSpans(ctx, spans)
+		}
+		return err
+	}
+
+	stream.mu.Lock()
+	stream.inFlightBytes += int64(len(payload))
+	stream.mu.Unlock()
+	defer func() {
+		stream.mu.Lock()
+		stream.inFlightBytes -= int64(len(payload))
+		stream.mu.Unlock()
+	}()
+
+	if err := stream.clientStream.SendMsg(payload); err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			e.mu.Lock()
+			e.usingFallback = true
+			e.mu.Unlock()
+			return e.fallback.ExportSpans(ctx, spans)
+		}
+		stream.mu.Lock()
+		stream.closed = true
+		stream.mu.Unlock()
+		return fmt.Errorf("otelsetup: arrow stream send failed: %w", err)
+	}
+
+	return nil
+}
+
+// encodeBatch packs spans into a single Arrow record batch. Each row carries
+// one span's bytes, OTLP-proto encoded, in the otlp_span_bytes column; the
+// full multivariate OTel-Arrow schema (separate columns per attribute) is
+// out of scope for this sample, but the batching/compression story is the
+// same: many spans travel as one columnar record instead of N unary calls.
+func (e *arrowExporter) encodeBatch(spans []sdktrace.ReadOnlySpan) ([]byte, error) {
+	b := array.NewRecordBuilder(e.alloc, arrowRecordSchema)
+	defer b.Release()
+
+	idBuilder := b.Field(0).(*array.Uint64Builder)
+	bytesBuilder := b.Field(1).(*array.BinaryBuilder)
+
+	batchID := e.nextID()
+	for _, span := range spans {
+		encoded, err := proto.Marshal(spanToOTLPProto(span))
+		if err != nil {
+			return nil, err
+		}
+		idBuilder.Append(batchID)
+		bytesBuilder.Append(encoded)
+	}
+
+	record := b.NewRecord()
+	defer record.Release()
+
+	var buf bytes.Buffer
+	w := ipc.NewWriter(&buf, ipc.WithSchema(arrowRecordSchema), ipc.WithAllocator(e.alloc))
+	if err := w.Write(record); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *arrowExporter) nextID() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.nextBatchID++
+	return e.nextBatchID
+}
+
+// spanToOTLPProto is a minimal ReadOnlySpan -> OTLP proto span converter
+// covering the fields this sample app cares about (name, ids); a production
+// exporter would reuse the SDK's internal transform package instead.
+func spanToOTLPProto(span sdktrace.ReadOnlySpan) *tracepb.Span {
+	sc := span.SpanContext()
+	traceID := sc.TraceID()
+	spanID := sc.SpanID()
+	return &tracepb.Span{
+		TraceId: traceID[:],
+		SpanId:  spanID[:],
+		Name:    span.Name(),
+	}
+}
+
+// Shutdown half-closes every open stream (CloseSend) before releasing the
+// underlying connection. ExportSpans sends each batch synchronously, so the
+// exporter holds no pending batches of its own to drain, and Shutdown does
+// not wait for responses on the streams. The fallback OTLP exporter is shut
+// down unconditionally: newSpanExporterWithArrow builds it (and dials its own
+// gRPC connection) up front regardless of whether Arrow streaming ever hits
+// Unimplemented, so it must be closed even when usingFallback was never
+// tripped or its connection leaks for the life of the process.
+func (e *arrowExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	streams := e.streams
+	e.streams = nil
+	e.mu.Unlock()
+
+	for _, s := range streams {
+		s.mu.Lock()
+		closed := s.closed
+		s.closed = true
+		s.mu.Unlock()
+		if closed {
+			continue
+		}
+		_ = s.clientStream.CloseSend()
+	}
+
+	var errs []error
+	if err := e.fallback.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("fallback exporter shutdown: %w", err))
+	}
+	if err := e.conn.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("arrow connection close: %w", err))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("otelsetup: arrow exporter shutdown errors: %v", errs)
+	}
+	return nil
+}
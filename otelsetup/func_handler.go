@@ -1,34 +1,105 @@
 package otelsetup
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	handlerMetricsOnce sync.Once
+	requestCounter     metric.Int64Counter
+	requestDuration    metric.Float64Histogram
 )
 
+// initHandlerMetrics creates the counter/histogram instruments the handlers
+// below record to, exactly once. Meter() is only populated once InitProvider
+// has run, so the instruments can't be created at package init time; handlers
+// call this on every request (each served on its own goroutine), so the
+// actual creation is guarded by sync.Once rather than a check-then-set on the
+// package vars.
+func initHandlerMetrics() {
+	handlerMetricsOnce.Do(func() {
+		requestCounter, _ = Meter().Int64Counter("http.server.request_count",
+			metric.WithDescription("Number of HTTP requests received, by route and status"),
+		)
+		requestDuration, _ = Meter().Float64Histogram("http.server.request_duration",
+			metric.WithDescription("HTTP request duration in milliseconds, by route and status"),
+			metric.WithUnit("ms"),
+		)
+	})
+}
+
+// FinishRequest records the request count and duration metrics for a single
+// handler invocation and emits a log record correlated to the active span.
+// Handlers outside this package (e.g. utils.GetProductsHandler) call this
+// directly rather than going through initHandlerMetrics, since it lazily
+// initializes the instruments itself.
+func FinishRequest(ctx context.Context, route string, status int, start time.Time) {
+	initHandlerMetrics()
+	elapsed := time.Since(start)
+	attrs := metric.WithAttributes(
+		attribute.String("route", route),
+		attribute.Int("status", status),
+	)
+	if requestCounter != nil {
+		requestCounter.Add(ctx, 1, attrs)
+	}
+	if requestDuration != nil {
+		requestDuration.Record(ctx, float64(elapsed.Milliseconds()), attrs)
+	}
+
+	LogWithSpan(ctx, log.SeverityInfo, "request completed",
+		log.String("route", route),
+		log.Int("status", status),
+		log.Int64("duration_ms", elapsed.Milliseconds()),
+	)
+}
+
 func HandleRequest(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	start := time.Now()
+	initHandlerMetrics()
+	route := "/"
+	status := http.StatusOK
 
 	// Handle database operations
 	if err := DatabaseCall(ctx); err != nil {
-		http.Error(w, fmt.Sprintf("Database error: %v", err), http.StatusInternalServerError)
+		status = http.StatusInternalServerError
+		http.Error(w, fmt.Sprintf("Database error: %v", err), status)
+		FinishRequest(ctx, route, status, start)
 		return
 	}
 
 	// Call external API
 	if err := ExternalAPICall(ctx); err != nil {
-		http.Error(w, fmt.Sprintf("External API error: %v", err), http.StatusInternalServerError)
+		status = http.StatusInternalServerError
+		http.Error(w, fmt.Sprintf("External API error: %v", err), status)
+		FinishRequest(ctx, route, status, start)
 		return
 	}
 
 	// Success response
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(status)
 	fmt.Fprintf(w, "Request processed successfully at %s\n", time.Now().Format(time.RFC3339))
+	FinishRequest(ctx, route, status, start)
 }
 
 func HandleSlowAPI(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	start := time.Now()
+	initHandlerMetrics()
+	route := "/api"
+
 	// Simulate slow processing
 	time.Sleep(2000 * time.Millisecond)
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "Slow API response at %s\n", time.Now().Format(time.RFC3339))
-}
\ No newline at end of file
+	FinishRequest(ctx, route, http.StatusOK, start)
+}
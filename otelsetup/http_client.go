@@ -0,0 +1,215 @@
+package otelsetup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultClientTimeout is used when ClientOption does not set one via
+// WithTimeout.
+const defaultClientTimeout = 10 * time.Second
+
+// clientConfig holds the options a ClientOption mutates.
+type clientConfig struct {
+	timeout        time.Duration
+	baseTransport  http.RoundTripper
+	redactedParams map[string]struct{}
+}
+
+// ClientOption configures an HTTPClient returned by NewHTTPClient.
+type ClientOption func(*clientConfig)
+
+// WithTimeout overrides the client's overall request timeout (default 10s).
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *clientConfig) { c.timeout = d }
+}
+
+// WithBaseTransport overrides the http.RoundTripper that otelhttp wraps
+// (default http.DefaultTransport), e.g. to share a connection pool tuned for
+// a specific upstream.
+func WithBaseTransport(rt http.RoundTripper) ClientOption {
+	return func(c *clientConfig) { c.baseTransport = rt }
+}
+
+// WithRedactedQueryParams marks query parameters (e.g. "api_key", "token")
+// whose values are replaced with "REDACTED" before being recorded on a span.
+func WithRedactedQueryParams(params ...string) ClientOption {
+	return func(c *clientConfig) {
+		for _, p := range params {
+			c.redactedParams[p] = struct{}{}
+		}
+	}
+}
+
+// HTTPClient is a shared, traced HTTP client. It wraps http.Client so callers
+// needing raw Do/Get still can, while GetJSON/PostJSON cover the common case
+// of calling a JSON API with a dedicated client span.
+type HTTPClient struct {
+	*http.Client
+	redactedParams map[string]struct{}
+}
+
+// NewHTTPClient returns an HTTPClient whose transport is wrapped with
+// otelhttp, so every outbound request propagates the active trace context
+// and is covered by a span named "<method> <route template>" (query strings
+// stripped from the span name, and redacted from the http.url attribute set
+// by GetJSON/PostJSON via WithRedactedQueryParams). Replaces the ad-hoc
+// otelhttp.Transport previously constructed inline per call site, so all
+// outbound HTTP shares one properly instrumented client.
+func NewHTTPClient(opts ...ClientOption) *HTTPClient {
+	cfg := &clientConfig{
+		timeout:        defaultClientTimeout,
+		baseTransport:  http.DefaultTransport,
+		redactedParams: make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	transport := otelhttp.NewTransport(cfg.baseTransport,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + routeTemplate(r.URL.Path)
+		}),
+	)
+
+	return &HTTPClient{
+		Client: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.timeout,
+		},
+		redactedParams: cfg.redactedParams,
+	}
+}
+
+// GetJSON starts a span named name, GETs rawURL with the trace context
+// injected by the underlying otelhttp transport, and decodes the JSON
+// response body into target (ignored if nil).
+func (c *HTTPClient) GetJSON(ctx context.Context, name, rawURL string, target interface{}) error {
+	return c.doJSON(ctx, name, http.MethodGet, rawURL, nil, target)
+}
+
+// PostJSON starts a span named name, POSTs body (JSON-encoded) to rawURL
+// with the trace context injected, and decodes the JSON response body into
+// target (ignored if nil).
+func (c *HTTPClient) PostJSON(ctx context.Context, name, rawURL string, body, target interface{}) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return fmt.Errorf("failed to encode request body: %w", err)
+		}
+	}
+	return c.doJSON(ctx, name, http.MethodPost, rawURL, &buf, target)
+}
+
+func (c *HTTPClient) doJSON(ctx context.Context, name, method, rawURL string, body *bytes.Buffer, target interface{}) error {
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(
+		attribute.String("http.method", method),
+		attribute.String("http.url", c.sanitizeURL(rawURL)),
+	))
+	defer span.End()
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = body
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reqBody)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	span.SetAttributes(
+		attribute.Int("http.status_code", resp.StatusCode),
+		attribute.Int("http.response_size", len(respBody)),
+	)
+
+	if resp.StatusCode >= 400 {
+		err := fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, rawURL)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	if target == nil || len(respBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, target); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to decode response body: %w", err)
+	}
+	return nil
+}
+
+// sanitizeURL replaces the value of every query parameter in redactedParams
+// with "REDACTED" before rawURL is recorded on a span. Returns rawURL
+// unchanged if it fails to parse or no parameters are configured.
+func (c *HTTPClient) sanitizeURL(rawURL string) string {
+	if len(c.redactedParams) == 0 {
+		return rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := parsed.Query()
+	for param := range c.redactedParams {
+		if q.Has(param) {
+			q.Set(param, "REDACTED")
+		}
+	}
+	parsed.RawQuery = q.Encode()
+	return parsed.String()
+}
+
+// routeTemplate collapses numeric path segments into "{id}" so span names
+// group by route rather than exploding per resource, e.g.
+// "/products/42" -> "/products/{id}".
+func routeTemplate(p string) string {
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		if seg != "" && isNumeric(seg) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
@@ -0,0 +1,150 @@
+package otelsetup
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// newSpanExporter builds the trace exporter named by OTEL_TRACES_EXPORTER
+// (otlp|stdout|none, default otlp). For "otlp" it honours
+// OTEL_EXPORTER_OTLP_PROTOCOL (grpc|http/protobuf, default grpc),
+// OTEL_EXPORTER_OTLP_HEADERS and OTEL_EXPORTER_OTLP_CERTIFICATE. If
+// OTEL_EXPORTER_JAEGER_ENDPOINT is set it takes priority and spans are sent
+// as OTLP/HTTP directly to that Jaeger collector endpoint, so users can point
+// at Jaeger without also running an OTel Collector.
+func newSpanExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")) {
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "none":
+		return nil, nil
+	}
+
+	if jaegerEndpoint := os.Getenv("OTEL_EXPORTER_JAEGER_ENDPOINT"); jaegerEndpoint != "" {
+		return otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(jaegerEndpoint))
+	}
+
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "127.0.0.1:4317"
+	}
+	headers := parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	creds, err := otlpTLSCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL") == "http/protobuf" {
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+		if len(headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(headers))
+		}
+		if creds == nil {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		} else {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(creds))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+
+	// Default: gRPC.
+	dialOpts := []grpc.DialOption{grpc.WithBlock()}
+	if creds != nil {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(creds)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	conn, err := grpc.DialContext(ctx, endpoint, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC connection to collector: %w", err)
+	}
+	grpcOpts := []otlptracegrpc.Option{otlptracegrpc.WithGRPCConn(conn)}
+	if len(headers) > 0 {
+		grpcOpts = append(grpcOpts, otlptracegrpc.WithHeaders(headers))
+	}
+	return otlptracegrpc.New(ctx, grpcOpts...)
+}
+
+// newSampler builds a Sampler from OTEL_TRACES_SAMPLER and
+// OTEL_TRACES_SAMPLER_ARG, matching the names defined by the OTel
+// specification (always_on, always_off, traceidratio,
+// parentbased_traceidratio, ...). Defaults to AlwaysSample, preserving prior
+// behaviour when unset.
+func newSampler() sdktrace.Sampler {
+	name := os.Getenv("OTEL_TRACES_SAMPLER")
+	ratio := 1.0
+	if arg := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); arg != "" {
+		if v, err := strconv.ParseFloat(arg, 64); err == nil {
+			ratio = v
+		}
+	}
+
+	switch name {
+	case "always_off":
+		return sdktrace.NeverSample()
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(ratio)
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample())
+	case "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+	case "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample())
+	case "always_on", "":
+		return sdktrace.AlwaysSample()
+	default:
+		return sdktrace.AlwaysSample()
+	}
+}
+
+// parseOTLPHeaders parses the comma-separated key=value pairs used by
+// OTEL_EXPORTER_OTLP_HEADERS (e.g. "api-key=secret,team=platform").
+func parseOTLPHeaders(raw string) map[string]string {
+	if raw == "" {
+		return nil
+	}
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		headers[kv[0]] = kv[1]
+	}
+	return headers
+}
+
+// otlpTLSCredentials loads a client TLS config from
+// OTEL_EXPORTER_OTLP_CERTIFICATE, a PEM file containing the CA certificate to
+// trust. Returns nil when unset, in which case callers fall back to
+// insecure credentials (matching the sample app's default of talking to a
+// local collector).
+func otlpTLSCredentials() (*tls.Config, error) {
+	certPath := os.Getenv("OTEL_EXPORTER_OTLP_CERTIFICATE")
+	if certPath == "" {
+		return nil, nil
+	}
+
+	pem, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OTEL_EXPORTER_OTLP_CERTIFICATE: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("failed to parse OTEL_EXPORTER_OTLP_CERTIFICATE as PEM")
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
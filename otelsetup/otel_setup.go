@@ -4,16 +4,20 @@ import (
     "context"
     "fmt"
     "os"
-	"io"
+    "strings"
 	"time"
-	"net/http"
 
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
     "go.opentelemetry.io/otel"
     "go.opentelemetry.io/otel/attribute"
     "go.opentelemetry.io/otel/codes"
-    "go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+    "go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+    "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+    "go.opentelemetry.io/otel/log"
+    logglobal "go.opentelemetry.io/otel/log/global"
+    "go.opentelemetry.io/otel/metric"
     "go.opentelemetry.io/otel/propagation"
+    sdklog "go.opentelemetry.io/otel/sdk/log"
+    sdkmetric "go.opentelemetry.io/otel/sdk/metric"
     "go.opentelemetry.io/otel/sdk/resource"
     sdktrace "go.opentelemetry.io/otel/sdk/trace"
     semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
@@ -22,42 +26,38 @@ import (
     "google.golang.org/grpc/credentials/insecure"
 )
 
-var tracer trace.Tracer
+var (
+	tracer trace.Tracer
+	meter  metric.Meter
+	logger log.Logger
+
+	tracerProvider *sdktrace.TracerProvider
+	meterProvider  *sdkmetric.MeterProvider
+	loggerProvider *sdklog.LoggerProvider
+)
 
 func InitProvider() (func(context.Context) error, error) {
     ctx := context.Background()
 
-    // Get collector endpoint from environment variable or use default
-    endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
-    if endpoint == "" {
-        endpoint = "127.0.0.1:4317"
-    }
-
-    // Set up a connection to the collector
-    conn, err := grpc.Dial(endpoint,
-        grpc.WithTransportCredentials(insecure.NewCredentials()),
-        grpc.WithBlock())
+    // Build the trace exporter/sampler from OTEL_TRACES_EXPORTER,
+    // OTEL_EXPORTER_OTLP_PROTOCOL, OTEL_TRACES_SAMPLER and friends instead of
+    // hard-coding gRPC + AlwaysSample. newSpanExporterWithArrow additionally
+    // swaps in the OTel-Arrow columnar exporter when enabled.
+    traceExporter, err := newSpanExporterWithArrow(ctx)
     if err != nil {
-        return nil, fmt.Errorf("failed to create gRPC connection to collector: %w", err)
-    }
-
-    // Set up a trace exporter
-    traceExporter, err := otlptracegrpc.New(ctx,
-        otlptracegrpc.WithGRPCConn(conn),
-        otlptracegrpc.WithInsecure(),
-    )
-    if err != nil {
-        return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+        return nil, err
     }
 
-    // Register the trace exporter with a TracerProvider using a batch
-    // span processor to aggregate spans before export.
-    batchSpanProcessor := sdktrace.NewBatchSpanProcessor(traceExporter)
-    tracerProvider := sdktrace.NewTracerProvider(
-        sdktrace.WithSampler(sdktrace.AlwaysSample()),
+    tracerProviderOpts := []sdktrace.TracerProviderOption{
+        sdktrace.WithSampler(newSampler()),
         sdktrace.WithResource(NewResource()),
-        sdktrace.WithSpanProcessor(batchSpanProcessor),
-    )
+    }
+    if traceExporter != nil {
+        // Register the trace exporter with a TracerProvider using a batch
+        // span processor to aggregate spans before export.
+        tracerProviderOpts = append(tracerProviderOpts, sdktrace.WithSpanProcessor(sdktrace.NewBatchSpanProcessor(traceExporter)))
+    }
+    tracerProvider = sdktrace.NewTracerProvider(tracerProviderOpts...)
     otel.SetTracerProvider(tracerProvider)
 
     // Set global propagator to tracecontext (the default is no-op)
@@ -69,8 +69,146 @@ func InitProvider() (func(context.Context) error, error) {
     // Create a tracer
     tracer = otel.GetTracerProvider().Tracer("demo-service")
 
+    // The metrics/logs pipelines share the collector connection dialed below,
+    // but only when traces are actually headed to a real collector. If
+    // OTEL_TRACES_EXPORTER selects stdout/none (the local-debugging path),
+    // there's no collector to assume is listening, so skip
+    // the dial entirely instead of blocking startup forever on it.
+    switch strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")) {
+    case "stdout", "none":
+        // Grab whatever Meter/Logger the global (no-op, absent a real
+        // provider) providers hand back, so Meter()/Logger() callers still
+        // get a usable instance instead of a nil interface.
+        meter = otel.GetMeterProvider().Meter("demo-service")
+        logger = logglobal.Logger("demo-service")
+        return Shutdown, nil
+    }
+
+    // Get collector endpoint from environment variable or use default.
+    endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+    if endpoint == "" {
+        endpoint = "127.0.0.1:4317"
+    }
+    conn, err := grpc.Dial(endpoint,
+        grpc.WithTransportCredentials(insecure.NewCredentials()),
+        grpc.WithBlock())
+    if err != nil {
+        return nil, fmt.Errorf("failed to create gRPC connection to collector: %w", err)
+    }
+
+	// Wire up the metrics and logs pillars alongside tracing so operators
+	// get all three signals out of the same collector endpoint.
+	if _, err := InitMeterProvider(ctx, conn); err != nil {
+		return nil, err
+	}
+	if _, err := InitLoggerProvider(ctx, conn); err != nil {
+		return nil, err
+	}
+
     // Return a function that can be called to clean up resources
-    return tracerProvider.Shutdown, nil
+    return Shutdown, nil
+}
+
+// InitMeterProvider configures an OTLP meter provider that exports over conn
+// (the collector connection InitProvider dials for metrics and logs; the
+// trace exporter dials its own), and registers it as the global MeterProvider.
+func InitMeterProvider(ctx context.Context, conn *grpc.ClientConn) (*sdkmetric.MeterProvider, error) {
+	metricExporter, err := otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithGRPCConn(conn))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+	}
+
+	meterProvider = sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(NewResource()),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+	)
+	otel.SetMeterProvider(meterProvider)
+	meter = otel.GetMeterProvider().Meter("demo-service")
+
+	return meterProvider, nil
+}
+
+// InitLoggerProvider configures an OTLP logger provider that exports over conn
+// (the collector connection InitProvider dials for metrics and logs; the
+// trace exporter dials its own), and registers it as the global LoggerProvider.
+func InitLoggerProvider(ctx context.Context, conn *grpc.ClientConn) (*sdklog.LoggerProvider, error) {
+	logExporter, err := otlploggrpc.New(ctx, otlploggrpc.WithGRPCConn(conn))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log exporter: %w", err)
+	}
+
+	loggerProvider = sdklog.NewLoggerProvider(
+		sdklog.WithResource(NewResource()),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(logExporter)),
+	)
+	logglobal.SetLoggerProvider(loggerProvider)
+	logger = logglobal.Logger("demo-service")
+
+	return loggerProvider, nil
+}
+
+// Meter returns the package-level Meter used to record metrics. InitProvider
+// must be called before using it.
+func Meter() metric.Meter {
+	return meter
+}
+
+// Logger returns the package-level Logger used to emit span-correlated log
+// records. InitProvider must be called before using it.
+func Logger() log.Logger {
+	return logger
+}
+
+// LogWithSpan emits a log record carrying the trace_id/span_id of the span
+// active in ctx (if any), so logs can be correlated back to the trace that
+// produced them.
+func LogWithSpan(ctx context.Context, severity log.Severity, message string, attrs ...log.KeyValue) {
+	if logger == nil {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if sc := span.SpanContext(); sc.IsValid() {
+		attrs = append(attrs,
+			log.String("trace_id", sc.TraceID().String()),
+			log.String("span_id", sc.SpanID().String()),
+		)
+	}
+
+	var record log.Record
+	record.SetTimestamp(time.Now())
+	record.SetSeverity(severity)
+	record.SetBody(log.StringValue(message))
+	record.AddAttributes(attrs...)
+
+	logger.Emit(ctx, record)
+}
+
+// Shutdown flushes and closes the tracer, meter and logger providers so no
+// buffered telemetry is lost on exit.
+func Shutdown(ctx context.Context) error {
+	var errs []error
+
+	if tracerProvider != nil {
+		if err := tracerProvider.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("tracer provider shutdown: %w", err))
+		}
+	}
+	if meterProvider != nil {
+		if err := meterProvider.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("meter provider shutdown: %w", err))
+		}
+	}
+	if loggerProvider != nil {
+		if err := loggerProvider.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("logger provider shutdown: %w", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("otelsetup: shutdown errors: %v", errs)
+	}
+	return nil
 }
 
 func NewResource() *resource.Resource {
@@ -114,6 +252,11 @@ func DatabaseCall(ctx context.Context) error {
 	return nil
 }
 
+// externalAPIClient is the shared traced client used for outbound calls to
+// the payment service, so they all go through one connection pool and one
+// otelhttp transport instead of constructing a client per call.
+var externalAPIClient = NewHTTPClient()
+
 // Simulate external API call
 func ExternalAPICall(ctx context.Context) error {
 	ctx, span := tracer.Start(ctx, "external.api.request", trace.WithAttributes(
@@ -122,24 +265,8 @@ func ExternalAPICall(ctx context.Context) error {
 	))
 	defer span.End()
 
-	// Create HTTP client with tracing
-	client := http.Client{
-		Transport: otelhttp.NewTransport(http.DefaultTransport),
-	}
-
-	// External API call
-	req, _ := http.NewRequestWithContext(ctx, "GET", "https://httpbin.org/get", nil)
-	
-	resp, err := client.Do(req)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return err
-	}
-	defer resp.Body.Close()
-	
-	_, err = io.ReadAll(resp.Body)
-	if err != nil {
+	var result map[string]interface{}
+	if err := externalAPIClient.GetJSON(ctx, "http.client.request", "https://httpbin.org/get", &result); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return err
@@ -0,0 +1,182 @@
+package otelsetup
+
+import (
+	"context"
+	"os"
+	"runtime/metrics"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultGCPauseThreshold is the individual GC pause duration above which a
+// span event is recorded, overridable via OTEL_RUNTIME_GC_PAUSE_THRESHOLD_MS.
+const defaultGCPauseThreshold = 50 * time.Millisecond
+
+// runtimeGaugeNames are the runtime/metrics keys sampled on every tick and
+// recorded as gauges. "/gc/pauses:seconds" is handled separately below since
+// it is a histogram sample, not a scalar.
+var runtimeGaugeNames = []string{
+	"/sched/goroutines:goroutines",
+	"/memory/classes/heap/objects:bytes",
+	"/gc/heap/allocs:bytes",
+}
+
+// StartRuntimeInstrumentation periodically records Go runtime metrics
+// (goroutines, heap alloc, GC pauses, CPU seconds) as OTel gauges/histograms
+// on the same OTLP pipeline as the rest of the app's telemetry, and attaches
+// a span event to the span active in ctx whenever a single GC pause exceeds
+// OTEL_RUNTIME_GC_PAUSE_THRESHOLD_MS (default 50ms). It runs until ctx is
+// cancelled, so callers should launch it in a goroutine.
+func StartRuntimeInstrumentation(ctx context.Context, interval time.Duration) {
+	gaugeInstruments := make(map[string]metric.Int64Gauge, len(runtimeGaugeNames))
+	for _, name := range runtimeGaugeNames {
+		g, _ := Meter().Int64Gauge("runtime.go." + metricSuffix(name))
+		gaugeInstruments[name] = g
+	}
+	gcPauseHistogram, _ := Meter().Float64Histogram("runtime.go.gc_pause",
+		metric.WithDescription("Individual GC STW pause durations in milliseconds"),
+		metric.WithUnit("ms"),
+	)
+	cpuSecondsGauge, _ := Meter().Float64Gauge("runtime.go.cpu_seconds_total",
+		metric.WithDescription("Cumulative CPU seconds consumed by the process"),
+	)
+
+	threshold := defaultGCPauseThreshold
+	if v := os.Getenv("OTEL_RUNTIME_GC_PAUSE_THRESHOLD_MS"); v != "" {
+		if ms, err := strconv.Atoi(v); err == nil {
+			threshold = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	samples := make([]metrics.Sample, 0, len(runtimeGaugeNames)+2)
+	for _, name := range runtimeGaugeNames {
+		samples = append(samples, metrics.Sample{Name: name})
+	}
+	samples = append(samples,
+		metrics.Sample{Name: "/gc/pauses:seconds"},
+		metrics.Sample{Name: "/cpu/classes/total:cpu-seconds"},
+	)
+
+	// Establish a baseline for the cumulative GC pause histogram before the
+	// loop starts. Without this, the first tick would treat every pause
+	// bucket's count-since-process-start as having "just happened" and
+	// misattribute pre-startup GC activity to whatever span is active in ctx
+	// at that first tick.
+	metrics.Read(samples)
+	var lastGCCounts []float64
+	for _, s := range samples {
+		if s.Name == "/gc/pauses:seconds" {
+			lastGCCounts = baselineGCCounts(s.Value.Float64Histogram())
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			metrics.Read(samples)
+
+			for _, s := range samples {
+				switch s.Name {
+				case "/gc/pauses:seconds":
+					lastGCCounts = recordNewGCPauses(ctx, s.Value.Float64Histogram(), lastGCCounts, gcPauseHistogram, threshold)
+				case "/cpu/classes/total:cpu-seconds":
+					if cpuSecondsGauge != nil {
+						cpuSecondsGauge.Record(ctx, s.Value.Float64())
+					}
+				default:
+					if g, ok := gaugeInstruments[s.Name]; ok && g != nil {
+						g.Record(ctx, int64(s.Value.Uint64()))
+					}
+				}
+			}
+		}
+	}
+}
+
+// baselineGCCounts snapshots the current cumulative count in every bucket of
+// h, for seeding recordNewGCPauses' prevCounts before any ticks have run.
+func baselineGCCounts(h *metrics.Float64Histogram) []float64 {
+	if h == nil {
+		return nil
+	}
+	counts := make([]float64, len(h.Counts))
+	for i, c := range h.Counts {
+		counts[i] = float64(c)
+	}
+	return counts
+}
+
+// recordNewGCPauses records every GC pause bucket count that increased since
+// the previous tick into histogram, and emits a span event on the span
+// active in ctx for each increase whose bucket upper bound exceeds
+// threshold. It returns the updated per-bucket counts for the next tick.
+func recordNewGCPauses(ctx context.Context, h *metrics.Float64Histogram, prevCounts []float64, histogram metric.Float64Histogram, threshold time.Duration) []float64 {
+	if h == nil {
+		return prevCounts
+	}
+
+	counts := make([]float64, len(h.Counts))
+	span := trace.SpanFromContext(ctx)
+
+	for i, count := range h.Counts {
+		counts[i] = float64(count)
+		prev := 0.0
+		if i < len(prevCounts) {
+			prev = prevCounts[i]
+		}
+		newSamples := counts[i] - prev
+		if newSamples <= 0 {
+			continue
+		}
+
+		bucketSeconds := h.Buckets[i+1]
+		bucketMS := bucketSeconds * 1000
+
+		if histogram != nil {
+			for n := 0.0; n < newSamples; n++ {
+				histogram.Record(ctx, bucketMS)
+			}
+		}
+
+		if time.Duration(bucketSeconds*float64(time.Second)) > threshold {
+			span.AddEvent("gc.pause", trace.WithAttributes(
+				attribute.Float64("gc.pause.duration_ms", bucketMS),
+				attribute.Int("gc.pause.count", int(newSamples)),
+			))
+		}
+	}
+
+	return counts
+}
+
+// metricSuffix turns a runtime/metrics key like "/sched/goroutines:goroutines"
+// into a short OTel instrument suffix like "goroutines".
+func metricSuffix(name string) string {
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '/' {
+			if colon := indexByte(name[i+1:], ':'); colon >= 0 {
+				return name[i+1:][:colon]
+			}
+			return name[i+1:]
+		}
+	}
+	return name
+}
+
+func indexByte(s string, b byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == b {
+			return i
+		}
+	}
+	return -1
+}
@@ -9,16 +9,41 @@ import (
 	"math/rand"
 
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/XSAM/otelsql"
 	"github.com/gorilla/mux" // Included as per your import, though not used in this specific code
+	"otel-go-app-example/messaging" // Import messaging to access kafka producer/consumer helpers
 	"otel-go-app-example/otelsetup" // Import otelsetup to access HandleSlowAPI
 	"net/http/httptest" // Add this import
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 	// "go.opentelemetry.io/otel/trace"
 	"context" // Add this import
+	"sync"
 )
 
+var (
+	dbMetricsOnce   sync.Once
+	dbQueryDuration metric.Float64Histogram
+)
+
+// initDBMetrics creates the db.query duration histogram exactly once.
+// Meter() is only populated once otelsetup.InitProvider has run; GetAllProducts
+// calls this on every request (each served on its own goroutine), so the
+// actual creation is guarded by sync.Once rather than a check-then-set on
+// dbQueryDuration.
+func initDBMetrics() {
+	dbMetricsOnce.Do(func() {
+		dbQueryDuration, _ = otelsetup.Meter().Float64Histogram("db.query.duration",
+			metric.WithDescription("Duration of database queries in milliseconds, by query"),
+			metric.WithUnit("ms"),
+		)
+	})
+}
+
 // Product represents the structure of the products table
 type Product struct {
 	ID       uint    `json:"id"`
@@ -38,14 +63,65 @@ type Response struct {
 // DB is a global variable to hold the database connection (optional, can be passed as a parameter instead)
 var DB *sql.DB
 
-// InitDB initializes the database connection
+// KafkaProducer is a global variable to hold the Kafka producer used by
+// PublishHandler, mirroring how DB is held for the MySQL connection.
+var KafkaProducer *messaging.Producer
+
+// InitMessaging wires up the Kafka producer for the given brokers/topic.
+func InitMessaging(brokers []string, topic string) {
+	KafkaProducer = messaging.NewProducer(brokers, topic)
+}
+
+// PublishHandler publishes a demo event to Kafka so a single trace can be
+// followed from the HTTP request through the producer, the broker and the
+// background consumer.
+func PublishHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	start := time.Now()
+	const route = "/publish"
+
+	if KafkaProducer == nil {
+		status := http.StatusInternalServerError
+		http.Error(w, "kafka producer not initialized; call InitMessaging first", status)
+		otelsetup.FinishRequest(ctx, route, status, start)
+		return
+	}
+
+	event := fmt.Sprintf(`{"event":"demo.event","published_at":"%s"}`, time.Now().Format(time.RFC3339))
+	if err := KafkaProducer.Publish(ctx, []byte("demo-event"), []byte(event)); err != nil {
+		status := http.StatusInternalServerError
+		http.Error(w, fmt.Sprintf("failed to publish event: %v", err), status)
+		otelsetup.FinishRequest(ctx, route, status, start)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "published event at %s\n", time.Now().Format(time.RFC3339))
+	otelsetup.FinishRequest(ctx, route, http.StatusAccepted, start)
+}
+
+// InitDB initializes the database connection. It registers an otelsql-wrapped
+// driver so every QueryContext/ExecContext/BeginTx/Ping automatically gets a
+// child span with semconv DB attributes and errors recorded, and so
+// db.client.connections.* metrics are emitted from DB.Stats() periodically.
 func InitDB(username, password string) error {
 	// Data Source Name (DSN) format: username:password@tcp(host:port)/dbname?charset=utf8
 	dsn := fmt.Sprintf("%s:%s@tcp(127.0.0.1:3306)/inventory?charset=utf8", username, password)
-	
+
+	driverName, err := otelsql.Register("mysql",
+		otelsql.WithAttributes(semconv.DBSystemMySQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			Ping:                 true,
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("error registering instrumented mysql driver: %v", err)
+	}
+
 	// Open database connection
-	var err error
-	DB, err = sql.Open("mysql", dsn)
+	DB, err = sql.Open(driverName, dsn)
 	if err != nil {
 		return fmt.Errorf("error opening database: %v", err)
 	}
@@ -56,6 +132,10 @@ func InitDB(username, password string) error {
 		return fmt.Errorf("error connecting to the database: %v", err)
 	}
 
+	if _, err := otelsql.RegisterDBStatsMetrics(DB, otelsql.WithAttributes(semconv.DBSystemMySQL)); err != nil {
+		return fmt.Errorf("error registering db stats metrics: %v", err)
+	}
+
 	fmt.Println("Successfully connected to the MySQL database!")
 	return nil
 }
@@ -64,6 +144,8 @@ func InitDB(username, password string) error {
 func GetAllProducts(ctx context.Context) ([]Product, error) {
 	_, span := otel.Tracer("utils").Start(ctx, "GetAllProducts")
 	defer span.End()
+	initDBMetrics()
+	queryStart := time.Now()
 
 	if DB == nil {
 		span.SetAttributes(attribute.String("error", "database not initialized"))
@@ -72,6 +154,10 @@ func GetAllProducts(ctx context.Context) ([]Product, error) {
 	}
 	query := "SELECT id, name, quantity, price FROM products"
 	rows, err := DB.QueryContext(ctx, query)
+	if dbQueryDuration != nil {
+		dbQueryDuration.Record(ctx, float64(time.Since(queryStart).Milliseconds()),
+			metric.WithAttributes(attribute.String("query", "GetAllProducts")))
+	}
 	if err != nil {
 		span.SetAttributes(attribute.String("error", err.Error()))
 		return nil, fmt.Errorf("error querying products: %v", err)
@@ -126,22 +212,29 @@ func processData(ctx context.Context, products []Product) (string, error) {
 // GetProductsHandler handles the /products endpoint with a complex accident scenario
 func GetProductsHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	start := time.Now()
+	const route = "/products"
 	tracer := otel.Tracer("utils")
 	_, span := tracer.Start(ctx, "GetProductsHandler")
 	defer span.End()
+	otelsetup.LogWithSpan(ctx, log.SeverityInfo, "GetProductsHandler invoked")
 
 	// Step 1: Validate the request
 	if err := validateRequest(ctx); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		status := http.StatusBadRequest
+		http.Error(w, err.Error(), status)
 		span.SetAttributes(attribute.String("error", err.Error()))
+		otelsetup.FinishRequest(ctx, route, status, start)
 		return
 	}
 
 	// Step 2: Fetch products from the database
 	products, err := GetAllProducts(ctx)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to fetch products: %v", err), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		http.Error(w, fmt.Sprintf("Failed to fetch products: %v", err), status)
 		span.SetAttributes(attribute.String("error", err.Error()))
+		otelsetup.FinishRequest(ctx, route, status, start)
 		return
 	}
 
@@ -160,8 +253,10 @@ func GetProductsHandler(w http.ResponseWriter, r *http.Request) {
 	// Step 4: Process the data concurrently
 	processStatus, err := processData(ctx, products)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to process data: %v", err), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		http.Error(w, fmt.Sprintf("Failed to process data: %v", err), status)
 		span.SetAttributes(attribute.String("error", err.Error()))
+		otelsetup.FinishRequest(ctx, route, status, start)
 		return
 	}
 
@@ -182,8 +277,10 @@ func GetProductsHandler(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		span.SetAttributes(attribute.String("error", err.Error()))
 		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		otelsetup.FinishRequest(ctx, route, http.StatusInternalServerError, start)
 		return
 	}
+	otelsetup.FinishRequest(ctx, route, http.StatusOK, start)
 }
 
 // SetupRouter configures the Gorilla Mux router with all endpoints
@@ -196,6 +293,7 @@ func SetupRouter() *mux.Router {
 	}).Methods("GET")
 	r.HandleFunc("/api", otelsetup.HandleSlowAPI).Methods("GET")
 	r.HandleFunc("/products", GetProductsHandler).Methods("GET")
+	r.HandleFunc("/publish", PublishHandler).Methods("POST")
 	return r
 }
 